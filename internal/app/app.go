@@ -19,11 +19,7 @@ import (
 
 // Run builds the runtime components from config and blocks until shutdown.
 func Run(ctx context.Context, cfg *config.Config) error {
-	monitorCfg := monitor.Config{
-		Enabled:     cfg.ManagementEnabled(),
-		Listen:      cfg.Management.Listen,
-		ProbeTarget: cfg.Management.ProbeTarget,
-	}
+	monitorCfg := buildMonitorConfig(cfg)
 	monitorMgr, err := monitor.NewManager(monitorCfg)
 	if err != nil {
 		return fmt.Errorf("init monitor: %w", err)
@@ -43,6 +39,7 @@ func Run(ctx context.Context, cfg *config.Config) error {
 
 	ctx = box.Context(ctx, inboundRegistry, outboundRegistry, endpointRegistry, dnsRegistry, serviceRegistry)
 	ctx = monitor.ContextWith(ctx, monitorMgr)
+	monitorMgr.Start(ctx)
 
 	instance, err := box.New(box.Options{Context: ctx, Options: buildResult})
 	if err != nil {
@@ -70,3 +67,30 @@ func Run(ctx context.Context, cfg *config.Config) error {
 	}
 	return instance.Close()
 }
+
+// buildMonitorConfig translates the user-facing management settings into
+// monitor.Config.
+func buildMonitorConfig(cfg *config.Config) monitor.Config {
+	authTokens := make([]monitor.AuthToken, 0, len(cfg.Management.AuthTokens))
+	for _, t := range cfg.Management.AuthTokens {
+		scopes := make([]monitor.Scope, 0, len(t.Scopes))
+		for _, s := range t.Scopes {
+			scopes = append(scopes, monitor.Scope(s))
+		}
+		authTokens = append(authTokens, monitor.AuthToken{Token: t.Token, Scopes: scopes})
+	}
+
+	return monitor.Config{
+		Enabled:          cfg.ManagementEnabled(),
+		Listen:           cfg.Management.Listen,
+		ProbeTarget:      cfg.Management.ProbeTarget,
+		StatePath:        cfg.Management.StatePath,
+		AuthTokens:       authTokens,
+		OIDCIssuer:       cfg.Management.OIDCIssuer,
+		TrustedNetworks:  cfg.Management.TrustedNetworks,
+		ProbeInterval:    cfg.Management.ProbeInterval,
+		ProbeJitter:      cfg.Management.ProbeJitter,
+		ProbeConcurrency: cfg.Management.ProbeConcurrency,
+		ProbeTimeout:     cfg.Management.ProbeTimeout,
+	}
+}
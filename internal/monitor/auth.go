@@ -0,0 +1,405 @@
+package monitor
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scope is a permission granted to a bearer token.
+type Scope string
+
+const (
+	ScopeRead    Scope = "read"
+	ScopeProbe   Scope = "probe"
+	ScopeRelease Scope = "release"
+	ScopeAdmin   Scope = "admin"
+)
+
+// AuthToken is a single static bearer token and the scopes it grants.
+type AuthToken struct {
+	Token  string
+	Scopes []Scope
+}
+
+// oidcRefreshInterval is how often the OIDC JWKS is re-fetched.
+const oidcRefreshInterval = time.Hour
+
+// authenticator enforces bearer-token and OIDC authentication for the
+// monitor HTTP API, with a trusted-network bypass for local deployments.
+type authenticator struct {
+	tokens       map[string]map[Scope]bool
+	trusted      []*net.IPNet
+	oidc         *oidcVerifier
+	unconfigured bool
+	// initErr holds an error from newAuthenticator, if any. A non-nil
+	// initErr makes authorize fail closed: a security gate that cannot be
+	// set up correctly must reject everything, not allow everything.
+	initErr error
+}
+
+func newAuthenticator(cfg Config) (*authenticator, error) {
+	a := &authenticator{tokens: make(map[string]map[Scope]bool)}
+
+	for _, t := range cfg.AuthTokens {
+		scopes := make(map[Scope]bool, len(t.Scopes))
+		for _, s := range t.Scopes {
+			scopes[s] = true
+		}
+		a.tokens[t.Token] = scopes
+	}
+
+	trusted := cfg.TrustedNetworks
+	if len(trusted) == 0 {
+		trusted = []string{"127.0.0.1/32", "::1/128"}
+	}
+	for _, cidr := range trusted {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("parse trusted network %q: %w", cidr, err)
+		}
+		a.trusted = append(a.trusted, network)
+	}
+
+	if cfg.OIDCIssuer != "" {
+		v, err := newOIDCVerifier(cfg.OIDCIssuer)
+		if err != nil {
+			return nil, err
+		}
+		a.oidc = v
+	}
+
+	a.unconfigured = len(a.tokens) == 0 && a.oidc == nil
+	return a, nil
+}
+
+// brokenAuthenticator returns an authenticator that rejects every request,
+// including ones that would otherwise qualify for the trusted-network
+// bypass. Use it when newAuthenticator fails, so a misconfigured or
+// transiently unreachable auth source fails closed instead of disabling
+// auth entirely.
+func brokenAuthenticator(err error) *authenticator {
+	return &authenticator{initErr: err}
+}
+
+// statusForDenied reports the HTTP status a caller should use when
+// authorize returns false: 503 when auth itself is broken, 401 when the
+// request was simply unauthenticated/unauthorized.
+func (a *authenticator) statusForDenied() int {
+	if a != nil && a.initErr != nil {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusUnauthorized
+}
+
+// startRefresh launches the background JWKS refresh loop, if OIDC is
+// configured. It stops when ctx is cancelled.
+func (a *authenticator) startRefresh(ctx context.Context, logger interface{ Printf(string, ...any) }) {
+	if a.oidc == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(oidcRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := a.oidc.refresh(); err != nil {
+					logger.Printf("monitor: failed to refresh OIDC JWKS: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// authorize reports whether r may proceed for the given required scope.
+func (a *authenticator) authorize(r *http.Request, required Scope) bool {
+	if a.initErr != nil {
+		return false
+	}
+	if a.unconfigured || a.fromTrustedNetwork(r) {
+		return true
+	}
+
+	token, ok := bearerToken(r)
+	if !ok {
+		return false
+	}
+
+	for candidate, scopes := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			return scopes[required] || scopes[ScopeAdmin]
+		}
+	}
+
+	if a.oidc != nil {
+		claims, err := a.oidc.verify(token)
+		if err != nil {
+			return false
+		}
+		scopes := scopesFromClaims(claims)
+		return scopes[required] || scopes[ScopeAdmin]
+	}
+	return false
+}
+
+func (a *authenticator) fromTrustedNetwork(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range a.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if !strings.HasPrefix(header, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(header, prefix), true
+}
+
+// requireScope wraps h so it only runs once authorize grants access,
+// otherwise replying 401 (or 503 if auth itself failed to initialize).
+func (s *Server) requireScope(scope Scope, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.auth == nil || s.auth.authorize(r, scope) {
+			h(w, r)
+			return
+		}
+		w.WriteHeader(s.auth.statusForDenied())
+		writeJSON(w, map[string]any{"error": "unauthorized"})
+	}
+}
+
+// jwk is a single entry of a JSON Web Key Set, restricted to the RSA fields
+// this package needs to verify RS256 tokens.
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type oidcDiscovery struct {
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// oidcVerifier validates RS256-signed bearer tokens against an issuer's
+// published JWKS.
+type oidcVerifier struct {
+	issuer  string
+	jwksURI string
+
+	mu   sync.RWMutex
+	keys map[string]*rsa.PublicKey
+}
+
+func newOIDCVerifier(issuer string) (*oidcVerifier, error) {
+	v := &oidcVerifier{issuer: issuer, keys: make(map[string]*rsa.PublicKey)}
+
+	var discovery oidcDiscovery
+	if err := fetchJSON(strings.TrimRight(issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("discover OIDC issuer %s: %w", issuer, err)
+	}
+	v.jwksURI = discovery.JWKSURI
+
+	if err := v.refresh(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (v *oidcVerifier) refresh() error {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := fetchJSON(v.jwksURI, &set); err != nil {
+		return err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		pub, err := rsaPublicKeyFromJWK(k)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.mu.Unlock()
+	return nil
+}
+
+// verify checks token's signature against the cached JWKS and its time-bound
+// and issuer claims via validateClaims, and returns its decoded claims on
+// success. Scope policy is left to callers.
+func (v *oidcVerifier) verify(token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("oidc: malformed token")
+	}
+
+	var header struct {
+		Kid string `json:"kid"`
+		Alg string `json:"alg"`
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, err
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("oidc: unsupported alg %q", header.Alg)
+	}
+
+	v.mu.RLock()
+	key, ok := v.keys[header.Kid]
+	v.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("oidc: unknown key id %q", header.Kid)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	signed := parts[0] + "." + parts[1]
+	digest := sha256.Sum256([]byte(signed))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, err
+	}
+
+	payloadJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	var claims map[string]any
+	if err := json.Unmarshal(payloadJSON, &claims); err != nil {
+		return nil, err
+	}
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// validateClaims checks the standard time-bound and issuer claims. It does
+// not check audience, since no expected audience is configured.
+func (v *oidcVerifier) validateClaims(claims map[string]any) error {
+	now := time.Now()
+
+	exp, ok := claimTime(claims, "exp")
+	if !ok {
+		return fmt.Errorf("oidc: token missing exp claim")
+	}
+	if now.After(exp) {
+		return fmt.Errorf("oidc: token expired at %s", exp)
+	}
+
+	if nbf, ok := claimTime(claims, "nbf"); ok && now.Before(nbf) {
+		return fmt.Errorf("oidc: token not valid until %s", nbf)
+	}
+
+	iss, _ := claims["iss"].(string)
+	if iss != v.issuer {
+		return fmt.Errorf("oidc: unexpected issuer %q", iss)
+	}
+
+	return nil
+}
+
+// claimTime reads a numeric (seconds-since-epoch) claim as a time.Time.
+func claimTime(claims map[string]any, name string) (time.Time, bool) {
+	v, ok := claims[name]
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+// scopesFromClaims derives the granted scopes from a verified token's
+// "scope" claim (an OAuth2-style space-delimited string) or "scopes" claim
+// (a JSON array of strings). A token with neither claim grants no scopes.
+func scopesFromClaims(claims map[string]any) map[Scope]bool {
+	scopes := make(map[Scope]bool)
+
+	if raw, ok := claims["scope"].(string); ok {
+		for _, s := range strings.Fields(raw) {
+			scopes[Scope(s)] = true
+		}
+	}
+
+	if raw, ok := claims["scopes"].([]any); ok {
+		for _, s := range raw {
+			if str, ok := s.(string); ok {
+				scopes[Scope(str)] = true
+			}
+		}
+	}
+
+	return scopes
+}
+
+func rsaPublicKeyFromJWK(k jwk) (*rsa.PublicKey, error) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	eBytes, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	n := new(big.Int).SetBytes(nBytes)
+	e := new(big.Int).SetBytes(eBytes)
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+// fetchJSON is a small helper kept here to avoid importing an HTTP client
+// library just for issuer/JWKS discovery.
+func fetchJSON(url string, out any) error {
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s fetching %s", resp.Status, url)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
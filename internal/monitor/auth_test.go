@@ -0,0 +1,74 @@
+package monitor
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthenticatorAuthorize(t *testing.T) {
+	auth, err := newAuthenticator(Config{
+		AuthTokens: []AuthToken{
+			{Token: "read-token", Scopes: []Scope{ScopeRead}},
+			{Token: "admin-token", Scopes: []Scope{ScopeAdmin}},
+		},
+		TrustedNetworks: []string{"10.0.0.0/8"},
+	})
+	if err != nil {
+		t.Fatalf("newAuthenticator: %v", err)
+	}
+
+	cases := []struct {
+		name       string
+		remoteAddr string
+		token      string
+		required   Scope
+		want       bool
+	}{
+		{name: "trusted network bypasses auth entirely", remoteAddr: "10.1.2.3:5555", required: ScopeAdmin, want: true},
+		{name: "untrusted network with no token is denied", remoteAddr: "203.0.113.1:5555", required: ScopeRead, want: false},
+		{name: "token with matching scope is allowed", remoteAddr: "203.0.113.1:5555", token: "read-token", required: ScopeRead, want: true},
+		{name: "token missing the required scope is denied", remoteAddr: "203.0.113.1:5555", token: "read-token", required: ScopeRelease, want: false},
+		{name: "admin scope grants any required scope", remoteAddr: "203.0.113.1:5555", token: "admin-token", required: ScopeRelease, want: true},
+		{name: "unknown token is denied", remoteAddr: "203.0.113.1:5555", token: "not-a-real-token", required: ScopeRead, want: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/nodes", nil)
+			r.RemoteAddr = tc.remoteAddr
+			if tc.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			if got := auth.authorize(r, tc.required); got != tc.want {
+				t.Fatalf("authorize() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticatorUnconfiguredAllowsEverything(t *testing.T) {
+	auth, err := newAuthenticator(Config{})
+	if err != nil {
+		t.Fatalf("newAuthenticator: %v", err)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/api/nodes", nil)
+	r.RemoteAddr = "203.0.113.1:5555"
+	if !auth.authorize(r, ScopeAdmin) {
+		t.Fatal("authorize() = false for an unconfigured authenticator, want true")
+	}
+}
+
+func TestAuthenticatorFailsClosedOnInitError(t *testing.T) {
+	auth := brokenAuthenticator(errors.New("boom"))
+
+	r := httptest.NewRequest(http.MethodGet, "/api/nodes", nil)
+	r.RemoteAddr = "127.0.0.1:5555"
+	if auth.authorize(r, ScopeRead) {
+		t.Fatal("authorize() = true for a broken authenticator, want false even from a trusted network")
+	}
+	if got := auth.statusForDenied(); got != http.StatusServiceUnavailable {
+		t.Fatalf("statusForDenied() = %d, want %d", got, http.StatusServiceUnavailable)
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -19,7 +21,41 @@ type Config struct {
 	Enabled     bool
 	Listen      string
 	ProbeTarget string
-}
+	// LatencyEWMAAlpha is the smoothing factor used when updating each
+	// node's exponentially weighted moving average latency. It defaults
+	// to defaultLatencyEWMAAlpha when left at zero.
+	LatencyEWMAAlpha float64
+	// StatePath, when set, enables persisting blacklist/failure history to
+	// a JSON file at this path so it survives restarts.
+	StatePath string
+	// AuthTokens are static bearer tokens accepted by the monitor API,
+	// each with its own set of granted scopes.
+	AuthTokens []AuthToken
+	// OIDCIssuer, when set, additionally accepts bearer tokens signed by
+	// this OIDC issuer, validated against its published JWKS.
+	OIDCIssuer string
+	// TrustedNetworks lists CIDRs exempt from authentication. Defaults to
+	// loopback only when left empty.
+	TrustedNetworks []string
+	// ProbeInterval enables the background probe scheduler when set,
+	// controlling how often each node is probed automatically.
+	ProbeInterval time.Duration
+	// ProbeJitter randomizes each node's next probe time by up to this
+	// much in either direction, to avoid thundering-herd probes.
+	ProbeJitter time.Duration
+	// ProbeConcurrency bounds how many probes may run at once. Defaults to
+	// defaultProbeConcurrency when left at zero.
+	ProbeConcurrency int
+	// ProbeTimeout bounds each individual probe call. Defaults to 10s when
+	// left at zero.
+	ProbeTimeout time.Duration
+}
+
+// latencyRingSize bounds how many recent latency samples are kept per node
+// for percentile calculations.
+const latencyRingSize = 128
+
+const defaultLatencyEWMAAlpha = 0.3
 
 // NodeInfo is static metadata about a proxy entry.
 type NodeInfo struct {
@@ -43,6 +79,10 @@ type Snapshot struct {
 	LastSuccess       time.Time     `json:"last_success,omitempty"`
 	LastProbeLatency  time.Duration `json:"last_probe_latency,omitempty"`
 	LastLatencyMs     int64         `json:"last_latency_ms"`
+	LatencyP50Ms      int64         `json:"latency_p50_ms"`
+	LatencyP95Ms      int64         `json:"latency_p95_ms"`
+	LatencyEWMAMs     float64       `json:"latency_ewma_ms"`
+	SuccessRate       float64       `json:"success_rate"`
 }
 
 type probeFunc func(ctx context.Context) (time.Duration, error)
@@ -53,18 +93,33 @@ type EntryHandle struct {
 }
 
 type entry struct {
-	info      NodeInfo
-	failure   int
-	blacklist bool
-	until     time.Time
-	lastError string
-	lastFail  time.Time
-	lastOK    time.Time
-	lastProbe time.Duration
-	active    atomic.Int32
-	probe     probeFunc
-	release   releaseFunc
-	mu        sync.RWMutex
+	mgr         *Manager
+	info        NodeInfo
+	failure     int
+	blacklist   bool
+	until       time.Time
+	lastError   string
+	lastFail    time.Time
+	lastOK      time.Time
+	lastProbe   time.Duration
+	active      atomic.Int32
+	probe       probeFunc
+	release     releaseFunc
+	alpha       float64
+	latencyRing [latencyRingSize]time.Duration
+	ringPos     int
+	ringCount   int
+	ewmaMs      float64
+	attempts    int64
+	successes   int64
+	mu          sync.RWMutex
+
+	saveMu    sync.Mutex
+	saveTimer *time.Timer
+
+	nextProbeAt   time.Time
+	probeBackoff  int
+	probeInFlight bool
 }
 
 // Manager aggregates all node states for the UI/API.
@@ -74,11 +129,69 @@ type Manager struct {
 	probeReady bool
 	mu         sync.RWMutex
 	nodes      map[string]*entry
+	store      Store
+
+	subMu       sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+// Event is a single node state change, published to subscribers registered
+// via Subscribe.
+type Event struct {
+	Type  string    `json:"type"`
+	Tag   string    `json:"tag"`
+	Error string    `json:"error,omitempty"`
+	Time  time.Time `json:"ts"`
+}
+
+// eventSubscriberBuffer bounds how many pending events a slow subscriber may
+// accumulate before it is dropped.
+const eventSubscriberBuffer = 32
+
+// Subscribe registers a new event subscriber and returns its channel along
+// with a function to unregister it. The returned channel is closed once
+// unsubscribe is called.
+func (m *Manager) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, eventSubscriberBuffer)
+	m.subMu.Lock()
+	if m.subscribers == nil {
+		m.subscribers = make(map[chan Event]struct{})
+	}
+	m.subscribers[ch] = struct{}{}
+	m.subMu.Unlock()
+
+	unsubscribe := func() {
+		m.subMu.Lock()
+		if _, ok := m.subscribers[ch]; ok {
+			delete(m.subscribers, ch)
+			close(ch)
+		}
+		m.subMu.Unlock()
+	}
+	return ch, unsubscribe
+}
+
+// publish fans ev out to every subscriber. A subscriber whose buffer is full
+// is dropped (unregistered and its channel closed) rather than blocking.
+func (m *Manager) publish(ev Event) {
+	m.subMu.Lock()
+	defer m.subMu.Unlock()
+	for ch := range m.subscribers {
+		select {
+		case ch <- ev:
+		default:
+			delete(m.subscribers, ch)
+			close(ch)
+		}
+	}
 }
 
 // NewManager constructs a manager and pre-validates the probe target.
 func NewManager(cfg Config) (*Manager, error) {
 	m := &Manager{cfg: cfg, nodes: make(map[string]*entry)}
+	if cfg.StatePath != "" {
+		m.store = NewJSONFileStore(cfg.StatePath)
+	}
 	if cfg.ProbeTarget != "" {
 		host, port, err := net.SplitHostPort(cfg.ProbeTarget)
 		if err != nil {
@@ -105,14 +218,42 @@ func (m *Manager) Register(info NodeInfo) *EntryHandle {
 	defer m.mu.Unlock()
 	e, ok := m.nodes[info.Tag]
 	if !ok {
-		e = &entry{info: info}
+		alpha := m.cfg.LatencyEWMAAlpha
+		if alpha <= 0 {
+			alpha = defaultLatencyEWMAAlpha
+		}
+		e = &entry{mgr: m, info: info, alpha: alpha, nextProbeAt: time.Now()}
 		m.nodes[info.Tag] = e
+		m.hydrate(e)
 	} else {
 		e.info = info
 	}
 	return &EntryHandle{ref: e}
 }
 
+// hydrate restores e's failure/blacklist history from the store, if one is
+// configured and has a record for this tag. A blacklist deadline still in
+// the future is preserved so a flapping node stays blacklisted across a
+// restart.
+func (m *Manager) hydrate(e *entry) {
+	if m.store == nil {
+		return
+	}
+	state, err := m.store.Load(e.info.Tag)
+	if err != nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.failure = state.Failure
+	e.lastFail = state.LastFailure
+	e.lastOK = state.LastSuccess
+	if state.Blacklist && time.Now().Before(state.Until) {
+		e.blacklist = true
+		e.until = state.Until
+	}
+}
+
 // DestinationForProbe exposes the configured destination for health checks.
 func (m *Manager) DestinationForProbe() (M.Socksaddr, bool) {
 	if !m.probeReady {
@@ -169,6 +310,18 @@ func (m *Manager) Release(tag string) error {
 	return nil
 }
 
+// Handle returns a handle to tag's existing entry, or nil if the node is
+// unknown. Unlike Register, it never creates or mutates an entry, so it is
+// safe to call from hot paths like a dial that merely wants to record
+// stats for a node registered elsewhere.
+func (m *Manager) Handle(tag string) *EntryHandle {
+	e, err := m.entry(tag)
+	if err != nil {
+		return nil
+	}
+	return &EntryHandle{ref: e}
+}
+
 func (m *Manager) entry(tag string) (*entry, error) {
 	m.mu.RLock()
 	e, ok := m.nodes[tag]
@@ -179,6 +332,68 @@ func (m *Manager) entry(tag string) (*entry, error) {
 	return e, nil
 }
 
+// WriteMetrics renders per-node counters and gauges in Prometheus text
+// exposition format.
+func (m *Manager) WriteMetrics(w io.Writer) {
+	m.mu.RLock()
+	list := make([]*entry, 0, len(m.nodes))
+	for _, e := range m.nodes {
+		list = append(list, e)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].info.Name < list[j].info.Name
+	})
+
+	fmt.Fprintln(w, "# HELP easy_proxies_node_active_connections Current number of active connections through the node.")
+	fmt.Fprintln(w, "# TYPE easy_proxies_node_active_connections gauge")
+	fmt.Fprintln(w, "# HELP easy_proxies_node_failures_total Total number of recorded failures for the node.")
+	fmt.Fprintln(w, "# TYPE easy_proxies_node_failures_total counter")
+	fmt.Fprintln(w, "# HELP easy_proxies_node_blacklisted Whether the node is currently blacklisted.")
+	fmt.Fprintln(w, "# TYPE easy_proxies_node_blacklisted gauge")
+	fmt.Fprintln(w, "# HELP easy_proxies_node_last_probe_latency_seconds Latency of the most recent probe.")
+	fmt.Fprintln(w, "# TYPE easy_proxies_node_last_probe_latency_seconds gauge")
+	fmt.Fprintln(w, "# HELP easy_proxies_node_last_success_timestamp_seconds Unix timestamp of the last successful use of the node.")
+	fmt.Fprintln(w, "# TYPE easy_proxies_node_last_success_timestamp_seconds gauge")
+
+	for _, e := range list {
+		e.writeMetrics(w)
+	}
+}
+
+func (e *entry) writeMetrics(w io.Writer) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	labels := fmt.Sprintf(`tag="%s",name="%s",mode="%s"`,
+		escapeMetricLabel(e.info.Tag), escapeMetricLabel(e.info.Name), escapeMetricLabel(e.info.Mode))
+
+	blacklisted := 0
+	if e.blacklist {
+		blacklisted = 1
+	}
+
+	fmt.Fprintf(w, "easy_proxies_node_active_connections{%s} %d\n", labels, e.active.Load())
+	fmt.Fprintf(w, "easy_proxies_node_failures_total{%s} %d\n", labels, e.failure)
+	fmt.Fprintf(w, "easy_proxies_node_blacklisted{%s} %d\n", labels, blacklisted)
+	if e.lastProbe > 0 {
+		fmt.Fprintf(w, "easy_proxies_node_last_probe_latency_seconds{%s} %g\n", labels, e.lastProbe.Seconds())
+	}
+	if !e.lastOK.IsZero() {
+		fmt.Fprintf(w, "easy_proxies_node_last_success_timestamp_seconds{%s} %d\n", labels, e.lastOK.Unix())
+	}
+}
+
+// escapeMetricLabel escapes a string for use as a Prometheus text-format
+// label value, per the exposition format's backslash/quote/newline rules.
+func escapeMetricLabel(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return s
+}
+
 func (e *entry) snapshot() Snapshot {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
@@ -188,6 +403,13 @@ func (e *entry) snapshot() Snapshot {
 		latencyMs = e.lastProbe.Milliseconds()
 	}
 
+	p50, p95 := e.latencyPercentilesLocked()
+
+	var successRate float64
+	if e.attempts > 0 {
+		successRate = float64(e.successes) / float64(e.attempts)
+	}
+
 	return Snapshot{
 		NodeInfo:          e.info,
 		FailureCount:      e.failure,
@@ -199,35 +421,112 @@ func (e *entry) snapshot() Snapshot {
 		LastSuccess:       e.lastOK,
 		LastProbeLatency:  e.lastProbe,
 		LastLatencyMs:     latencyMs,
+		LatencyP50Ms:      p50,
+		LatencyP95Ms:      p95,
+		LatencyEWMAMs:     e.ewmaMs,
+		SuccessRate:       successRate,
 	}
 }
 
+// latencyPercentilesLocked computes the p50/p95 latency in milliseconds from
+// the ring buffer. Callers must hold e.mu.
+func (e *entry) latencyPercentilesLocked() (p50Ms, p95Ms int64) {
+	if e.ringCount == 0 {
+		return 0, 0
+	}
+	scratch := make([]time.Duration, e.ringCount)
+	copy(scratch, e.latencyRing[:e.ringCount])
+	sort.Slice(scratch, func(i, j int) bool { return scratch[i] < scratch[j] })
+
+	p50 := scratch[(len(scratch)-1)*50/100]
+	p95 := scratch[(len(scratch)-1)*95/100]
+	return p50.Milliseconds(), p95.Milliseconds()
+}
+
 func (e *entry) recordFailure(err error) {
+	ts := time.Now()
 	e.mu.Lock()
-	defer e.mu.Unlock()
 	e.failure++
 	e.lastError = err.Error()
-	e.lastFail = time.Now()
+	e.lastFail = ts
+	e.attempts++
+	tag := e.info.Tag
+	e.mu.Unlock()
+	e.publish(Event{Type: "failure", Tag: tag, Error: err.Error(), Time: ts})
+	e.scheduleSave()
 }
 
 func (e *entry) recordSuccess() {
+	ts := time.Now()
 	e.mu.Lock()
-	e.lastOK = time.Now()
+	e.lastOK = ts
+	e.attempts++
+	e.successes++
+	tag := e.info.Tag
 	e.mu.Unlock()
+	e.publish(Event{Type: "success", Tag: tag, Time: ts})
+	e.scheduleSave()
 }
 
 func (e *entry) blacklistUntil(until time.Time) {
+	ts := time.Now()
 	e.mu.Lock()
 	e.blacklist = true
 	e.until = until
+	tag := e.info.Tag
 	e.mu.Unlock()
+	e.publish(Event{Type: "blacklisted", Tag: tag, Time: ts})
+	e.scheduleSave()
 }
 
 func (e *entry) clearBlacklist() {
+	ts := time.Now()
 	e.mu.Lock()
 	e.blacklist = false
 	e.until = time.Time{}
+	tag := e.info.Tag
 	e.mu.Unlock()
+	e.publish(Event{Type: "cleared", Tag: tag, Time: ts})
+	e.scheduleSave()
+}
+
+// publish forwards ev to the owning manager, if any.
+func (e *entry) publish(ev Event) {
+	if e.mgr != nil {
+		e.mgr.publish(ev)
+	}
+}
+
+// scheduleSave debounces persisting e's state to the manager's store,
+// coalescing rapid successive mutations into a single write.
+func (e *entry) scheduleSave() {
+	if e.mgr == nil || e.mgr.store == nil {
+		return
+	}
+	e.saveMu.Lock()
+	defer e.saveMu.Unlock()
+	if e.saveTimer != nil {
+		e.saveTimer.Stop()
+	}
+	e.saveTimer = time.AfterFunc(saveDebounce, e.persist)
+}
+
+// persist writes e's current state to the manager's store.
+func (e *entry) persist() {
+	if e.mgr == nil || e.mgr.store == nil {
+		return
+	}
+	e.mu.RLock()
+	state := persistedState{
+		Failure:     e.failure,
+		Blacklist:   e.blacklist,
+		Until:       e.until,
+		LastFailure: e.lastFail,
+		LastSuccess: e.lastOK,
+	}
+	tag := e.info.Tag
+	e.mu.RUnlock()
+	_ = e.mgr.store.Save(tag, state)
 }
 
 func (e *entry) incActive() {
@@ -253,9 +552,40 @@ func (e *entry) setRelease(fn releaseFunc) {
 func (e *entry) recordProbeLatency(d time.Duration) {
 	e.mu.Lock()
 	e.lastProbe = d
+	e.recordLatencySampleLocked(d)
+	// A probe reaching here succeeded, so it counts towards SuccessRate the
+	// same way recordSuccess does; otherwise SuccessRate would be biased
+	// downward for nodes that are only ever probed, never carry traffic.
+	e.attempts++
+	e.successes++
+	e.mu.Unlock()
+}
+
+// recordTrafficLatency folds a real-traffic latency sample into the same
+// ring buffer and EWMA used by probes, without touching lastProbe.
+func (e *entry) recordTrafficLatency(d time.Duration) {
+	e.mu.Lock()
+	e.recordLatencySampleLocked(d)
 	e.mu.Unlock()
 }
 
+// recordLatencySampleLocked appends a latency sample to the ring buffer and
+// updates the EWMA. Callers must hold e.mu.
+func (e *entry) recordLatencySampleLocked(d time.Duration) {
+	e.latencyRing[e.ringPos] = d
+	e.ringPos = (e.ringPos + 1) % latencyRingSize
+	if e.ringCount < latencyRingSize {
+		e.ringCount++
+	}
+
+	sampleMs := float64(d.Milliseconds())
+	if e.ewmaMs == 0 {
+		e.ewmaMs = sampleMs
+	} else {
+		e.ewmaMs = e.alpha*sampleMs + (1-e.alpha)*e.ewmaMs
+	}
+}
+
 // RecordFailure updates failure counters.
 func (h *EntryHandle) RecordFailure(err error) {
 	if h == nil || h.ref == nil {
@@ -304,6 +634,15 @@ func (h *EntryHandle) DecActive() {
 	h.ref.decActive()
 }
 
+// RecordTrafficLatency folds a real-traffic latency sample into the node's
+// latency ring buffer and EWMA, separately from probe latency.
+func (h *EntryHandle) RecordTrafficLatency(d time.Duration) {
+	if h == nil || h.ref == nil {
+		return
+	}
+	h.ref.recordTrafficLatency(d)
+}
+
 // SetProbe assigns a probe function.
 func (h *EntryHandle) SetProbe(fn func(ctx context.Context) (time.Duration, error)) {
 	if h == nil || h.ref == nil {
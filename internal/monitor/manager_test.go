@@ -0,0 +1,66 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEntryLatencyPercentilesLocked(t *testing.T) {
+	cases := []struct {
+		name    string
+		samples []time.Duration
+		wantP50 int64
+		wantP95 int64
+	}{
+		{
+			name:    "empty",
+			samples: nil,
+			wantP50: 0,
+			wantP95: 0,
+		},
+		{
+			name:    "single_sample",
+			samples: []time.Duration{50 * time.Millisecond},
+			wantP50: 50,
+			wantP95: 50,
+		},
+		{
+			name: "ten_ascending_samples",
+			samples: []time.Duration{
+				10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond, 40 * time.Millisecond, 50 * time.Millisecond,
+				60 * time.Millisecond, 70 * time.Millisecond, 80 * time.Millisecond, 90 * time.Millisecond, 100 * time.Millisecond,
+			},
+			wantP50: 50,
+			wantP95: 90,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := &entry{alpha: defaultLatencyEWMAAlpha}
+			for _, s := range tc.samples {
+				e.recordLatencySampleLocked(s)
+			}
+			p50, p95 := e.latencyPercentilesLocked()
+			if p50 != tc.wantP50 || p95 != tc.wantP95 {
+				t.Fatalf("latencyPercentilesLocked() = (%d, %d), want (%d, %d)", p50, p95, tc.wantP50, tc.wantP95)
+			}
+		})
+	}
+}
+
+func TestEntryLatencyRingWrapsAtCapacity(t *testing.T) {
+	e := &entry{alpha: defaultLatencyEWMAAlpha}
+	for i := 0; i < latencyRingSize+10; i++ {
+		e.recordLatencySampleLocked(time.Duration(i) * time.Millisecond)
+	}
+	if e.ringCount != latencyRingSize {
+		t.Fatalf("ringCount = %d, want %d after exceeding capacity", e.ringCount, latencyRingSize)
+	}
+	// The oldest 10 samples (0..9ms) should have been overwritten, so the
+	// minimum surviving sample is 10ms.
+	p50, _ := e.latencyPercentilesLocked()
+	if p50 < 10 {
+		t.Fatalf("p50 = %dms, want >= 10ms once the ring has wrapped", p50)
+	}
+}
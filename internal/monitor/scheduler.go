@@ -0,0 +1,155 @@
+package monitor
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// schedulerTick is how often the scheduler loop wakes up to check which
+// nodes are due for a background probe. Per-node cadence is governed by
+// Config.ProbeInterval, not this constant.
+const schedulerTick = time.Second
+
+// maxProbeBackoffMultiplier caps the factor by which repeated doubling may
+// grow a failing node's effective probe interval.
+const maxProbeBackoffMultiplier = 8
+
+// defaultProbeConcurrency bounds the worker pool when Config.ProbeConcurrency
+// is left unset.
+const defaultProbeConcurrency = 4
+
+// Start launches the background probe scheduler, if Config.ProbeInterval is
+// configured. It runs until ctx is cancelled.
+func (m *Manager) Start(ctx context.Context) {
+	if m.cfg.ProbeInterval <= 0 {
+		return
+	}
+	go m.runScheduler(ctx)
+}
+
+func (m *Manager) runScheduler(ctx context.Context) {
+	concurrency := m.cfg.ProbeConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultProbeConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.dispatchDueProbes(ctx, sem)
+		}
+	}
+}
+
+// dispatchDueProbes probes every node whose schedule has come due, through
+// the bounded worker pool backed by sem.
+func (m *Manager) dispatchDueProbes(ctx context.Context, sem chan struct{}) {
+	m.mu.RLock()
+	list := make([]*entry, 0, len(m.nodes))
+	for _, e := range m.nodes {
+		list = append(list, e)
+	}
+	m.mu.RUnlock()
+
+	now := time.Now()
+	for _, e := range list {
+		if !e.claimProbe(now) {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			e.releaseProbe()
+			return
+		}
+		go func(e *entry) {
+			defer func() { <-sem; e.releaseProbe() }()
+			m.runProbe(ctx, e)
+		}(e)
+	}
+}
+
+func (m *Manager) runProbe(ctx context.Context, e *entry) {
+	probe := e.currentProbe()
+	if probe == nil {
+		return
+	}
+
+	timeout := m.cfg.ProbeTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	latency, err := probe(probeCtx)
+	if err != nil {
+		e.recordFailure(err)
+		e.scheduleNext(time.Now(), m.cfg, false)
+		return
+	}
+	e.recordProbeLatency(latency)
+	e.scheduleNext(time.Now(), m.cfg, true)
+}
+
+func (e *entry) currentProbe() probeFunc {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.probe
+}
+
+// claimProbe atomically checks whether e is due for a probe and, if so and
+// no probe is already in flight for it, marks it in flight and returns
+// true. This stops a probe slower than schedulerTick from being
+// re-dispatched on every subsequent tick until it completes.
+func (e *entry) claimProbe(now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.probe == nil || e.probeInFlight || now.Before(e.nextProbeAt) {
+		return false
+	}
+	e.probeInFlight = true
+	return true
+}
+
+// releaseProbe clears the in-flight marker set by claimProbe.
+func (e *entry) releaseProbe() {
+	e.mu.Lock()
+	e.probeInFlight = false
+	e.mu.Unlock()
+}
+
+// scheduleNext computes e's next probe time from cfg.ProbeInterval plus
+// jitter, doubling the effective interval on repeated failures up to
+// maxProbeBackoffMultiplier and resetting it on success.
+func (e *entry) scheduleNext(now time.Time, cfg Config, success bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if success {
+		e.probeBackoff = 1
+	} else if e.probeBackoff < maxProbeBackoffMultiplier {
+		if e.probeBackoff == 0 {
+			e.probeBackoff = 1
+		}
+		e.probeBackoff *= 2
+		if e.probeBackoff > maxProbeBackoffMultiplier {
+			e.probeBackoff = maxProbeBackoffMultiplier
+		}
+	}
+
+	interval := cfg.ProbeInterval * time.Duration(e.probeBackoff)
+	if cfg.ProbeJitter > 0 {
+		interval += time.Duration(rand.Int63n(int64(cfg.ProbeJitter)*2)) - cfg.ProbeJitter
+	}
+	if interval < 0 {
+		interval = 0
+	}
+	e.nextProbeAt = now.Add(interval)
+}
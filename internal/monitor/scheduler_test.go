@@ -0,0 +1,78 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEntryScheduleNextBackoff(t *testing.T) {
+	cfg := Config{ProbeInterval: 10 * time.Second}
+	now := time.Now()
+	e := &entry{}
+
+	e.scheduleNext(now, cfg, false)
+	if e.probeBackoff != 2 {
+		t.Fatalf("after first failure: probeBackoff = %d, want 2", e.probeBackoff)
+	}
+	if want := now.Add(20 * time.Second); !e.nextProbeAt.Equal(want) {
+		t.Fatalf("after first failure: nextProbeAt = %v, want %v", e.nextProbeAt, want)
+	}
+
+	e.scheduleNext(now, cfg, false)
+	if e.probeBackoff != 4 {
+		t.Fatalf("after second failure: probeBackoff = %d, want 4", e.probeBackoff)
+	}
+
+	e.scheduleNext(now, cfg, false)
+	if e.probeBackoff != 8 {
+		t.Fatalf("after third failure: probeBackoff = %d, want 8", e.probeBackoff)
+	}
+
+	// Further failures must not grow the multiplier past the cap.
+	e.scheduleNext(now, cfg, false)
+	if e.probeBackoff != maxProbeBackoffMultiplier {
+		t.Fatalf("after fourth failure: probeBackoff = %d, want capped at %d", e.probeBackoff, maxProbeBackoffMultiplier)
+	}
+
+	// A success resets the backoff to the base interval.
+	e.scheduleNext(now, cfg, true)
+	if e.probeBackoff != 1 {
+		t.Fatalf("after success: probeBackoff = %d, want 1", e.probeBackoff)
+	}
+	if want := now.Add(cfg.ProbeInterval); !e.nextProbeAt.Equal(want) {
+		t.Fatalf("after success: nextProbeAt = %v, want %v", e.nextProbeAt, want)
+	}
+}
+
+func TestEntryScheduleNextJitterStaysWithinBounds(t *testing.T) {
+	cfg := Config{ProbeInterval: 10 * time.Second, ProbeJitter: 2 * time.Second}
+	now := time.Now()
+	e := &entry{probeBackoff: 1}
+
+	lower := now.Add(cfg.ProbeInterval - cfg.ProbeJitter)
+	upper := now.Add(cfg.ProbeInterval + cfg.ProbeJitter)
+
+	for i := 0; i < 50; i++ {
+		e.scheduleNext(now, cfg, true)
+		if e.nextProbeAt.Before(lower) || e.nextProbeAt.After(upper) {
+			t.Fatalf("nextProbeAt = %v, want within [%v, %v]", e.nextProbeAt, lower, upper)
+		}
+	}
+}
+
+func TestEntryClaimProbeRejectsInFlight(t *testing.T) {
+	now := time.Now()
+	e := &entry{probe: func(ctx context.Context) (time.Duration, error) { return 0, nil }, nextProbeAt: now}
+
+	if !e.claimProbe(now) {
+		t.Fatal("claimProbe() = false, want true for a due, idle entry")
+	}
+	if e.claimProbe(now) {
+		t.Fatal("claimProbe() = true, want false while a probe is already in flight")
+	}
+	e.releaseProbe()
+	if !e.claimProbe(now) {
+		t.Fatal("claimProbe() = false, want true once the in-flight probe has been released")
+	}
+}
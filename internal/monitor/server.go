@@ -4,6 +4,7 @@ import (
 	"context"
 	"embed"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"strings"
@@ -19,6 +20,7 @@ type Server struct {
 	mgr    *Manager
 	srv    *http.Server
 	logger *log.Logger
+	auth   *authenticator
 }
 
 // NewServer constructs a server; it can be nil when disabled.
@@ -29,11 +31,18 @@ func NewServer(cfg Config, mgr *Manager, logger *log.Logger) *Server {
 	if logger == nil {
 		logger = log.Default()
 	}
-	s := &Server{cfg: cfg, mgr: mgr, logger: logger}
+	auth, err := newAuthenticator(cfg)
+	if err != nil {
+		logger.Printf("monitor: failed to initialize auth, rejecting all requests until fixed: %v", err)
+		auth = brokenAuthenticator(err)
+	}
+	s := &Server{cfg: cfg, mgr: mgr, logger: logger, auth: auth}
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", s.handleIndex)
-	mux.HandleFunc("/api/nodes", s.handleNodes)
+	mux.HandleFunc("/api/nodes", s.requireScope(ScopeRead, s.handleNodes))
 	mux.HandleFunc("/api/nodes/", s.handleNodeAction)
+	mux.HandleFunc("/metrics", s.requireScope(ScopeRead, s.handleMetrics))
+	mux.HandleFunc("/api/events", s.requireScope(ScopeRead, s.handleEvents))
 	s.srv = &http.Server{Addr: cfg.Listen, Handler: mux}
 	return s
 }
@@ -44,6 +53,7 @@ func (s *Server) Start(ctx context.Context) {
 		return
 	}
 	s.logger.Printf("Starting monitor server on %s", s.cfg.Listen)
+	s.auth.startRefresh(ctx, s.logger)
 	go func() {
 		if err := s.srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			s.logger.Printf("❌ Monitor server error: %v", err)
@@ -86,6 +96,49 @@ func (s *Server) handleNodes(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, payload)
 }
 
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.mgr.WriteMetrics(w)
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	events, unsubscribe := s.mgr.Subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
+
 func (s *Server) handleNodeAction(w http.ResponseWriter, r *http.Request) {
 	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/nodes/"), "/")
 	if len(parts) < 1 {
@@ -107,6 +160,11 @@ func (s *Server) handleNodeAction(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
+		if !s.auth.authorize(r, ScopeProbe) {
+			w.WriteHeader(s.auth.statusForDenied())
+			writeJSON(w, map[string]any{"error": "unauthorized"})
+			return
+		}
 		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 		defer cancel()
 		latency, err := s.mgr.Probe(ctx, tag)
@@ -120,6 +178,11 @@ func (s *Server) handleNodeAction(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
+		if !s.auth.authorize(r, ScopeRelease) {
+			w.WriteHeader(s.auth.statusForDenied())
+			writeJSON(w, map[string]any{"error": "unauthorized"})
+			return
+		}
 		if err := s.mgr.Release(tag); err != nil {
 			writeJSON(w, map[string]any{"error": err.Error()})
 			return
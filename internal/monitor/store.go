@@ -0,0 +1,113 @@
+package monitor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// persistedState is the subset of entry state that survives restarts.
+type persistedState struct {
+	Failure     int       `json:"failure"`
+	Blacklist   bool      `json:"blacklist"`
+	Until       time.Time `json:"until"`
+	LastFailure time.Time `json:"last_failure"`
+	LastSuccess time.Time `json:"last_success"`
+}
+
+// Store persists blacklist/failure history per node tag so it survives
+// process restarts.
+type Store interface {
+	Load(tag string) (persistedState, error)
+	Save(tag string, state persistedState) error
+	Delete(tag string) error
+}
+
+// saveDebounce is how long an entry waits after its last mutation before
+// saving its state to the manager's Store, coalescing rapid successive
+// mutations into one Save.
+const saveDebounce = 2 * time.Second
+
+// JSONFileStore is the default Store, backing all tags onto a single JSON
+// file on disk.
+type JSONFileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewJSONFileStore constructs a store writing to path. The file and its
+// parent directory are created lazily on first Save.
+func NewJSONFileStore(path string) *JSONFileStore {
+	return &JSONFileStore{path: path}
+}
+
+func (s *JSONFileStore) readAllLocked() (map[string]persistedState, error) {
+	states := make(map[string]persistedState)
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return states, nil
+		}
+		return nil, err
+	}
+	if len(data) == 0 {
+		return states, nil
+	}
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+func (s *JSONFileStore) writeAllLocked(states map[string]persistedState) error {
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0o644)
+}
+
+// Load returns the persisted state for tag, or a zero value if none exists.
+func (s *JSONFileStore) Load(tag string) (persistedState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	states, err := s.readAllLocked()
+	if err != nil {
+		return persistedState{}, err
+	}
+	return states[tag], nil
+}
+
+// Save writes state for tag, replacing any previous entry.
+func (s *JSONFileStore) Save(tag string, state persistedState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	states, err := s.readAllLocked()
+	if err != nil {
+		return err
+	}
+	states[tag] = state
+	return s.writeAllLocked(states)
+}
+
+// Delete removes tag's persisted state, if any.
+func (s *JSONFileStore) Delete(tag string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	states, err := s.readAllLocked()
+	if err != nil {
+		return err
+	}
+	if _, ok := states[tag]; !ok {
+		return nil
+	}
+	delete(states, tag)
+	return s.writeAllLocked(states)
+}
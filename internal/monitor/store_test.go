@@ -0,0 +1,63 @@
+package monitor
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestManagerHydrateHonorsActiveCooldown(t *testing.T) {
+	store := NewJSONFileStore(filepath.Join(t.TempDir(), "state.json"))
+
+	future := time.Now().Add(time.Hour).Truncate(time.Second)
+	if err := store.Save("node-a", persistedState{Failure: 3, Blacklist: true, Until: future}); err != nil {
+		t.Fatalf("seed node-a: %v", err)
+	}
+
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := store.Save("node-b", persistedState{Failure: 1, Blacklist: true, Until: past}); err != nil {
+		t.Fatalf("seed node-b: %v", err)
+	}
+
+	m := &Manager{nodes: make(map[string]*entry), store: store}
+	m.Register(NodeInfo{Tag: "node-a"})
+	m.Register(NodeInfo{Tag: "node-b"})
+
+	byTag := make(map[string]Snapshot)
+	for _, snap := range m.Snapshot() {
+		byTag[snap.Tag] = snap
+	}
+
+	a := byTag["node-a"]
+	if !a.Blacklisted {
+		t.Fatalf("node-a: Blacklisted = false, want true (cooldown until %v has not elapsed)", future)
+	}
+	if !a.BlacklistedUntil.Equal(future) {
+		t.Fatalf("node-a: BlacklistedUntil = %v, want %v", a.BlacklistedUntil, future)
+	}
+	if a.FailureCount != 3 {
+		t.Fatalf("node-a: FailureCount = %d, want 3", a.FailureCount)
+	}
+
+	b := byTag["node-b"]
+	if b.Blacklisted {
+		t.Fatalf("node-b: Blacklisted = true, want false (cooldown until %v already elapsed)", past)
+	}
+	if b.FailureCount != 1 {
+		t.Fatalf("node-b: FailureCount = %d, want 1", b.FailureCount)
+	}
+}
+
+func TestManagerHydrateWithNoStoredStateLeavesEntryZeroed(t *testing.T) {
+	store := NewJSONFileStore(filepath.Join(t.TempDir(), "state.json"))
+	m := &Manager{nodes: make(map[string]*entry), store: store}
+
+	m.Register(NodeInfo{Tag: "node-new"})
+	snaps := m.Snapshot()
+	if len(snaps) != 1 {
+		t.Fatalf("got %d snapshots, want 1", len(snaps))
+	}
+	if snaps[0].Blacklisted || snaps[0].FailureCount != 0 {
+		t.Fatalf("got %+v, want zero-value failure/blacklist state", snaps[0])
+	}
+}
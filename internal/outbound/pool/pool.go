@@ -0,0 +1,226 @@
+// Package pool implements a "pool" outbound type that fans traffic out
+// across a set of member outbounds according to a pluggable selection
+// strategy.
+package pool
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"easy_proxies/internal/monitor"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-box/outbound"
+	M "github.com/sagernet/sing/common/metadata"
+)
+
+const outboundType = "pool"
+
+// Options configures a pool outbound.
+type Options struct {
+	option.DialerOptions
+	Outbounds []string `json:"outbounds"`
+	Strategy  string   `json:"strategy,omitempty"`
+	// ActiveConnectionCap excludes a candidate from the smart/p2c strategy
+	// once its ActiveConnections reaches this many, so an already-loaded
+	// member isn't handed more traffic. Zero means unbounded.
+	ActiveConnectionCap int32 `json:"active_connection_cap,omitempty"`
+}
+
+// Register installs the pool outbound type into registry, alongside the
+// other builtin outbound types sing-box already knows about.
+func Register(registry *outbound.Registry) {
+	outbound.Register[Options](registry, outboundType, NewOutbound)
+}
+
+// Outbound dispatches each connection to a member outbound chosen by the
+// configured Strategy.
+type Outbound struct {
+	adapter.OutboundAdapter
+	router   adapter.Router
+	logger   log.ContextLogger
+	tags     []string
+	strategy Strategy
+}
+
+// NewOutbound constructs a pool outbound from options.
+func NewOutbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options Options) (adapter.Outbound, error) {
+	if len(options.Outbounds) == 0 {
+		return nil, fmt.Errorf("pool: outbounds must not be empty")
+	}
+	strategy, err := newStrategy(options.Strategy, options.ActiveConnectionCap)
+	if err != nil {
+		return nil, err
+	}
+	return &Outbound{
+		OutboundAdapter: adapter.OutboundAdapter{
+			OutboundTag:  tag,
+			OutboundType: outboundType,
+		},
+		router:   router,
+		logger:   logger,
+		tags:     options.Outbounds,
+		strategy: strategy,
+	}, nil
+}
+
+// DialContext resolves the member outbound chosen by the active strategy,
+// dials through it, and times the dial into the monitor's real-traffic
+// latency stats when a monitor is attached to ctx.
+func (o *Outbound) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	member, err := o.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	conn, err := member.DialContext(ctx, network, destination)
+	if err == nil {
+		o.recordTrafficLatency(ctx, member.Tag(), time.Since(start))
+	}
+	return conn, err
+}
+
+// ListenPacket resolves the member outbound chosen by the active strategy
+// and opens a packet connection through it.
+func (o *Outbound) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	member, err := o.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return member.ListenPacket(ctx, destination)
+}
+
+func (o *Outbound) recordTrafficLatency(ctx context.Context, tag string, d time.Duration) {
+	mgr := monitor.FromContext(ctx)
+	if mgr == nil {
+		return
+	}
+	mgr.Handle(tag).RecordTrafficLatency(d)
+}
+
+func (o *Outbound) pick(ctx context.Context) (adapter.Outbound, error) {
+	candidates := make([]adapter.Outbound, 0, len(o.tags))
+	for _, tag := range o.tags {
+		member, ok := o.router.Outbound(tag)
+		if !ok {
+			continue
+		}
+		candidates = append(candidates, member)
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("pool: no member outbounds available")
+	}
+	return o.strategy.Select(ctx, candidates)
+}
+
+// Strategy selects a single outbound from a set of candidates for a new
+// connection.
+type Strategy interface {
+	// Select returns one of candidates, which is always non-empty.
+	Select(ctx context.Context, candidates []adapter.Outbound) (adapter.Outbound, error)
+}
+
+func newStrategy(name string, activeConnectionCap int32) (Strategy, error) {
+	switch name {
+	case "", "round_robin":
+		return &roundRobinStrategy{}, nil
+	case "smart", "p2c":
+		return &p2cStrategy{activeConnectionCap: activeConnectionCap}, nil
+	default:
+		return nil, fmt.Errorf("pool: unknown strategy %q", name)
+	}
+}
+
+// roundRobinStrategy cycles through candidates in order.
+type roundRobinStrategy struct {
+	mu   sync.Mutex
+	next int
+}
+
+func (s *roundRobinStrategy) Select(ctx context.Context, candidates []adapter.Outbound) (adapter.Outbound, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	picked := candidates[s.next%len(candidates)]
+	s.next++
+	return picked, nil
+}
+
+// p2cStrategy implements Power-of-Two-Choices, ranking candidates by EWMA
+// latency weighted by their current load, using stats kept by the monitor
+// attached to ctx via monitor.ContextWith. It falls back to round-robin
+// when no monitor is attached or fewer than two healthy candidates exist.
+type p2cStrategy struct {
+	fallback            roundRobinStrategy
+	activeConnectionCap int32
+}
+
+func (s *p2cStrategy) Select(ctx context.Context, candidates []adapter.Outbound) (adapter.Outbound, error) {
+	mgr := monitor.FromContext(ctx)
+	if mgr == nil {
+		return s.fallback.Select(ctx, candidates)
+	}
+
+	snapshots := make(map[string]monitor.Snapshot, len(candidates))
+	for _, snap := range mgr.Snapshot() {
+		snapshots[snap.Tag] = snap
+	}
+
+	healthy := healthyCandidates(snapshots, candidates, s.activeConnectionCap)
+	if len(healthy) < 2 {
+		return s.fallback.Select(ctx, candidates)
+	}
+
+	i, j := rand.Intn(len(healthy)), rand.Intn(len(healthy)-1)
+	if j >= i {
+		j++
+	}
+	a, b := healthy[i], healthy[j]
+	if scoreOf(snapshots, a.Tag()) <= scoreOf(snapshots, b.Tag()) {
+		return a, nil
+	}
+	return b, nil
+}
+
+// healthyCandidates filters out candidates the monitor reports as
+// blacklisted, or whose ActiveConnections has reached cap (when cap > 0).
+// Candidates the monitor has never seen are kept, since the monitor may
+// simply not be tracking that outbound type, and an untracked node has no
+// known active connections to cap.
+func healthyCandidates(snapshots map[string]monitor.Snapshot, candidates []adapter.Outbound, activeConnectionCap int32) []adapter.Outbound {
+	healthy := make([]adapter.Outbound, 0, len(candidates))
+	for _, c := range candidates {
+		snap, ok := snapshots[c.Tag()]
+		if !ok {
+			healthy = append(healthy, c)
+			continue
+		}
+		if snap.Blacklisted {
+			continue
+		}
+		if activeConnectionCap > 0 && snap.ActiveConnections >= activeConnectionCap {
+			continue
+		}
+		healthy = append(healthy, c)
+	}
+	return healthy
+}
+
+// scoreOf returns the p2c ranking score for tag: lower is better. A tag the
+// monitor hasn't recorded gets the worst possible score rather than the
+// best one, so an untracked node doesn't automatically win every matchup
+// against a measured one before it has any latency data of its own.
+func scoreOf(snapshots map[string]monitor.Snapshot, tag string) float64 {
+	snap, ok := snapshots[tag]
+	if !ok {
+		return math.MaxFloat64
+	}
+	return snap.LatencyEWMAMs * (1 + float64(snap.ActiveConnections))
+}